@@ -76,12 +76,14 @@
 package atexit
 
 import (
-	"fmt"
+	"context"
 	"os"
 	"runtime"
+	rtdebug "runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 var debug bool
@@ -96,28 +98,72 @@ func SetDebug(b bool) { debug = b }
 // Func represents an init or exit function.
 type Func struct {
 	Func func()
+
+	// Context is set instead of Func for a callback registered by
+	// OnInitContext or OnExitContext. It is always called with
+	// context.Background() except by ExecuteWithTimeout, which gives
+	// each exit callback a context bounded by a per-callback timeout.
+	Context func(context.Context) error
+
 	File string
 	Line int
 	Prio int
+
+	// Depth is the topological depth of a callback registered by
+	// OnInitNamed or OnExitNamed: the length of its longest dependency
+	// chain. It is always 0 for callbacks registered by the Prio-based
+	// functions, such as OnInit or OnExit.
+	Depth int
+
+	// id identifies the Handle returned when this Func was registered,
+	// so Scope.unregister can find it again. It is zero for a Func that
+	// was never registered through a Handle-returning function, such as
+	// one built by OnInitNamed or OnExitNamed.
+	id uint64
+}
+
+func (f Func) call(ctx context.Context) error {
+	if f.Context != nil {
+		return f.Context(ctx)
+	}
+	f.Func()
+	return nil
+}
+
+func (f Func) runInit() {
+	f.print("init")
+	if err := f.call(context.Background()); err != nil {
+		logger.Error("init func fails", "file", f.File, "line", f.Line, "prio", f.Prio, "error", err)
+	}
+}
+
+func (f Func) runExit() {
+	defer f.wrapPanic()
+	f.print("exit")
+	if err := f.call(context.Background()); err != nil {
+		logger.Error("exit func fails", "file", f.File, "line", f.Line, "prio", f.Prio, "error", err)
+	}
 }
 
-func (f Func) runInit() { f.print("init"); f.Func() }
-func (f Func) runExit() { defer f.wrapPanic(); f.print("exit"); f.Func() }
 func (f Func) wrapPanic() {
 	if r := recover(); r != nil {
-		const msg = "exit func panics: file=%s, line=%d, panic=%v\n"
-		fmt.Fprintf(os.Stderr, msg, f.File, f.Line, r)
+		logger.Error("exit func panics",
+			"file", f.File, "line", f.Line, "prio", f.Prio,
+			"panic", r, "stack", string(rtdebug.Stack()))
 	}
 }
 
 func (f Func) print(ftype string) {
 	if debug {
-		fmt.Printf("run %s func: file=%s, line=%d\n", ftype, f.File, f.Line)
+		logger.Debug("run func", "type", ftype, "file", f.File, "line", f.Line, "prio", f.Prio)
 	}
 }
 
 func sortfuncs(funcs []Func) {
 	sort.SliceStable(funcs, func(i, j int) bool {
+		if funcs[i].Depth != funcs[j].Depth {
+			return funcs[i].Depth < funcs[j].Depth
+		}
 		return funcs[i].Prio < funcs[j].Prio
 	})
 }
@@ -129,20 +175,38 @@ func runInits(funcs []Func) {
 }
 
 func runExits(funcs []Func) {
+	if executionMode == PriorityGroupsParallel {
+		runExitGroupsParallel(funcs)
+		return
+	}
+
 	for _len := len(funcs) - 1; _len >= 0; _len-- {
 		funcs[_len].runExit()
 	}
 }
 
-func registerCallback(funcs []Func, prefix string, skip, priority int, f func()) []Func {
+func registerCallback(s *Scope, funcs []Func, prefix string, skip, priority int, f func()) ([]Func, Handle) {
+	if f == nil {
+		panic(prefix + " function is nil")
+	}
+
+	file, line := getFileLine(skip + 2)
+	id := atomic.AddUint64(&s.nextID, 1)
+	funcs = append(funcs, Func{Prio: priority, Func: f, Line: line, File: file, id: id})
+	sortfuncs(funcs)
+	return funcs, Handle{id: id, scope: s}
+}
+
+func registerCallbackCtx(s *Scope, funcs []Func, prefix string, skip, priority int, f func(context.Context) error) ([]Func, Handle) {
 	if f == nil {
 		panic(prefix + " function is nil")
 	}
 
 	file, line := getFileLine(skip + 2)
-	funcs = append(funcs, Func{Prio: priority, Func: f, Line: line, File: file})
+	id := atomic.AddUint64(&s.nextID, 1)
+	funcs = append(funcs, Func{Prio: priority, Context: f, Line: line, File: file, id: id})
 	sortfuncs(funcs)
-	return funcs
+	return funcs, Handle{id: id, scope: s}
 }
 
 var trimPrefixes = []string{"/pkg/mod/", "/src/"}
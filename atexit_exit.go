@@ -21,38 +21,27 @@ import (
 	"time"
 )
 
-var (
-	executed    uint32
-	exitprio    = int64(99)
-	exitfuncs   = make([]Func, 0, 4)
-	exitexecch  = make(chan struct{})
-	ctx, cancel = context.WithCancel(context.Background())
-)
+const exitNamedPrefix = "atexit.OnExitNamed"
 
 func execute() {
-	if atomic.CompareAndSwapUint32(&executed, 0, 1) {
-		cancel()
-		runExits(exitfuncs)
-		close(exitexecch)
+	if atomic.CompareAndSwapUint32(&defaultScope.executed, 0, 1) {
+		defaultScope.cancel()
+		runExits(mergeNamed(defaultScope.getExitFuncs(), exitGraph, exitNamedPrefix))
+		close(defaultScope.exitexecch)
 	}
 }
 
-func registerExitCallback(priority int, exit func()) {
-	const prefix = "atexit.OnExitWithPriority: exit callback"
-	exitfuncs = registerCallback(exitfuncs, prefix, 2, priority, exit)
-}
-
 // GetAllExitFuncs returns all the registered exit functions.
 func GetAllExitFuncs() []Func {
-	return append([]Func{}, exitfuncs...)
+	return defaultScope.getExitFuncs()
 }
 
 // OnExitWithPriority registers the exit callback function with the priority,
 // which will be called when calling Exit.
 //
 // Notice: The bigger the value, the higher the priority.
-func OnExitWithPriority(priority int, callback func()) {
-	registerExitCallback(priority, callback)
+func OnExitWithPriority(priority int, callback func()) Handle {
+	return defaultScope.registerExit(priority, callback)
 }
 
 // OnExit is the same as OnExitWithPriority, but increase the priority
@@ -60,13 +49,44 @@ func OnExitWithPriority(priority int, callback func()) {
 //
 //	OnExit(callback) // ==> OnExitWithPriority(100, callback)
 //	OnExit(callback) // ==> OnExitWithPriority(101, callback)
-func OnExit(callback func()) {
-	registerExitCallback(int(atomic.AddInt64(&exitprio, 1)), callback)
+func OnExit(callback func()) Handle {
+	return defaultScope.registerExit(int(atomic.AddInt64(&defaultScope.exitprio, 1)), callback)
+}
+
+// OnExitContext is the same as OnExitWithPriority, but callback is called
+// with a context instead of taking no arguments, and may return an error,
+// which is logged instead of panicking the program.
+//
+// The context passed to callback is context.Background() unless the exit
+// functions are run by ExecuteWithTimeout, which cancels it once the
+// per-callback timeout or the total budget elapses, whichever is first.
+func OnExitContext(priority int, callback func(context.Context) error) Handle {
+	return defaultScope.registerExitCtx(priority, callback)
+}
+
+// OnExitNamed registers a named exit callback with the names of the
+// other named exit callbacks it depends on. Execute runs all of them in
+// the reverse of the topological order used by the matching
+// OnInitNamed graph, so a callback always runs before everything it
+// depends on; Prio is used as a tiebreaker among callbacks at the same
+// topological depth.
+//
+// Notice: deps may be registered in any order, but each of them must
+// have been registered by OnExitNamed by the time Execute is called, or
+// Execute panics when it resolves the graph. A dependency cycle panics
+// immediately, naming the offending chain.
+func OnExitNamed(name string, deps []string, fn func()) {
+	if fn == nil {
+		panic(exitNamedPrefix + ": exit callback is nil")
+	}
+
+	file, line := getFileLine(2)
+	exitGraph.register(exitNamedPrefix, name, deps, Func{Func: fn, File: file, Line: line})
 }
 
 // Context returns the context to indicate whether the registered exit funtions
 // are executed, that's, the function Execute is called.
-func Context() context.Context { return ctx }
+func Context() context.Context { return defaultScope.ctx }
 
 // Done is a convenient function that is equal to Context().Done().
 func Done() <-chan struct{} { return Context().Done() }
@@ -79,8 +99,70 @@ func Done() <-chan struct{} { return Context().Done() }
 // Notice: The exit functions are executed only once.
 func Execute() { execute() }
 
+// ExecuteWithTimeout is the same as Execute, but bounds the whole sequence
+// of exit functions to total and gives each one at most per to finish
+// before its context is cancelled and the sequence moves on to the next
+// exit function in priority order. The sequence also stops early once
+// total elapses.
+//
+// A per or total of zero or less means no limit for that budget.
+//
+// Unlike Execute, it always runs the exit functions one at a time,
+// whatever the mode set by SetExecutionMode.
+//
+// Notice: The exit functions are executed only once.
+func ExecuteWithTimeout(total, per time.Duration) {
+	if atomic.CompareAndSwapUint32(&defaultScope.executed, 0, 1) {
+		defaultScope.cancel()
+
+		octx := context.Background()
+		if total > 0 {
+			var tcancel context.CancelFunc
+			octx, tcancel = context.WithTimeout(octx, total)
+			defer tcancel()
+		}
+
+		runExitsWithTimeout(octx, mergeNamed(defaultScope.getExitFuncs(), exitGraph, exitNamedPrefix), per)
+		close(defaultScope.exitexecch)
+	}
+}
+
+func runExitsWithTimeout(octx context.Context, funcs []Func, per time.Duration) {
+	for _len := len(funcs) - 1; _len >= 0; _len-- {
+		if octx.Err() != nil {
+			return
+		}
+		funcs[_len].runExitWithTimeout(octx, per)
+	}
+}
+
+func (f Func) runExitWithTimeout(octx context.Context, per time.Duration) {
+	f.print("exit")
+
+	fctx := octx
+	if per > 0 {
+		var fcancel context.CancelFunc
+		fctx, fcancel = context.WithTimeout(octx, per)
+		defer fcancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer f.wrapPanic()
+		if err := f.call(fctx); err != nil {
+			logger.Error("exit func fails", "file", f.File, "line", f.Line, "prio", f.Prio, "error", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-fctx.Done():
+	}
+}
+
 // Wait waits until all the registered exit functions have finished to execute.
-func Wait() { <-exitexecch; time.Sleep(time.Millisecond * 10) }
+func Wait() { <-defaultScope.exitexecch; time.Sleep(time.Millisecond * 10) }
 
 // ExitFunc is used to customize the exit function.
 var ExitFunc = os.Exit
@@ -16,6 +16,8 @@ package atexit
 
 import (
 	"bytes"
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -42,3 +44,23 @@ func TestRegisterAndExecute(t *testing.T) {
 		t.Errorf("expect '%s', but got '%s'", expect, s)
 	}
 }
+
+func TestRunExitsWithTimeout(t *testing.T) {
+	var finished int32
+	slow := Func{Context: func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.AddInt32(&finished, 1)
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	runExitsWithTimeout(context.Background(), []Func{slow}, time.Millisecond*50)
+	if d := time.Since(start); d < time.Millisecond*50 {
+		t.Errorf("expect to wait for at least %s, but got %s", time.Millisecond*50, d)
+	}
+
+	time.Sleep(time.Millisecond * 10)
+	if v := atomic.LoadInt32(&finished); v != 1 {
+		t.Errorf("expect the slow callback to observe the cancelled context, but got %d", v)
+	}
+}
@@ -14,29 +14,22 @@
 
 package atexit
 
-import "sync/atomic"
-
-var (
-	initprio  = int64(99)
-	initfuncs = make([]Func, 0, 4)
+import (
+	"context"
+	"sync/atomic"
 )
 
-func registerInitCallback(priority int, init func()) {
-	const prefix = "atexit.OnInitWithPriority: init"
-	initfuncs = registerCallback(initfuncs, prefix, 2, priority, init)
-}
-
 // GetAllInitFuncs returns all the registered init functions.
 func GetAllInitFuncs() []Func {
-	return append([]Func{}, initfuncs...)
+	return defaultScope.getInitFuncs()
 }
 
 // OnInitWithPriority registers the init function with the priority,
 // which will be called when calling Init.
 //
 // Notice: The smaller the value, the higher the priority.
-func OnInitWithPriority(priority int, init func()) {
-	registerInitCallback(priority, init)
+func OnInitWithPriority(priority int, init func()) Handle {
+	return defaultScope.registerInit(priority, init)
 }
 
 // OnInit is the same as OnInitWithPriority, but increase the priority
@@ -44,12 +37,42 @@ func OnInitWithPriority(priority int, init func()) {
 //
 //	OnInit(init) // ==> OnInitWithPriority(100, init)
 //	OnInit(init) // ==> OnInitWithPriority(101, init)
-func OnInit(init func()) {
-	registerInitCallback(int(atomic.AddInt64(&initprio, 1)), init)
+func OnInit(init func()) Handle {
+	return defaultScope.registerInit(int(atomic.AddInt64(&defaultScope.initprio, 1)), init)
+}
+
+// OnInitContext is the same as OnInitWithPriority, but init is called
+// with context.Background() instead of taking no arguments, and may
+// return an error, which is logged instead of panicking the program.
+func OnInitContext(priority int, init func(context.Context) error) Handle {
+	return defaultScope.registerInitCtx(priority, init)
+}
+
+// OnInitNamed registers a named init callback with the names of the
+// other named init callbacks it depends on. Init runs a topological
+// sort over all of them so that each callback runs only after every
+// callback it depends on has already run; Prio is used as a tiebreaker
+// among callbacks at the same topological depth.
+//
+// Notice: deps may be registered in any order, but each of them must
+// have been registered by OnInitNamed by the time Init is called, or
+// Init panics when it resolves the graph. A dependency cycle panics
+// immediately, naming the offending chain.
+func OnInitNamed(name string, deps []string, fn func()) {
+	const prefix = "atexit.OnInitNamed"
+	if fn == nil {
+		panic(prefix + ": init function is nil")
+	}
+
+	file, line := getFileLine(2)
+	initGraph.register(prefix, name, deps, Func{Func: fn, File: file, Line: line})
 }
 
 // Init calls all the registered init functions.
 //
 // If setting the environment variable "DEBUG" to a true bool value
 // parsed by strconv.ParseBool, it will print the debug log to stdout.
-func Init() { runInits(initfuncs) }
+func Init() {
+	const prefix = "atexit.OnInitNamed"
+	runInits(mergeNamed(defaultScope.getInitFuncs(), initGraph, prefix))
+}
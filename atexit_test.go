@@ -22,15 +22,16 @@ import (
 func TestFuncs(t *testing.T) {
 	var skip int
 	var funcs []Func
+	scope := NewScope()
 	buf := bytes.NewBuffer(nil)
-	funcs = registerCallback(funcs, "test", skip, 0, func() { buf.WriteString("exit1\n") })
-	funcs = registerCallback(funcs, "test", skip, 3, func() { buf.WriteString("exit2\n") })
-	funcs = registerCallback(funcs, "test", skip, 3, func() { buf.WriteString("exit3\n") })
-	funcs = registerCallback(funcs, "test", skip, 2, func() { buf.WriteString("exit4\n") })
-	funcs = registerCallback(funcs, "test", skip, 1, func() { buf.WriteString("exit5\n") })
-	funcs = registerCallback(funcs, "test", skip, 2, func() { buf.WriteString("exit6\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 0, func() { buf.WriteString("exit1\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 3, func() { buf.WriteString("exit2\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 3, func() { buf.WriteString("exit3\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 2, func() { buf.WriteString("exit4\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 1, func() { buf.WriteString("exit5\n") })
+	funcs, _ = registerCallback(scope, funcs, "test", skip, 2, func() { buf.WriteString("exit6\n") })
 
-	expectlines := []int{26, 30, 29, 31, 27, 28}
+	expectlines := []int{27, 31, 30, 32, 28, 29}
 	for i, f := range funcs {
 		if line := expectlines[i]; line != f.Line {
 			t.Errorf("%d: expect line %d, but got %d", i, line, f.Line)
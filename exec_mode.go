@@ -0,0 +1,68 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import "sync"
+
+// ExecutionMode controls how Execute runs the registered exit callbacks.
+type ExecutionMode int
+
+const (
+	// Sequential runs the exit callbacks one after another in reverse
+	// priority order. This is the default.
+	Sequential ExecutionMode = iota
+
+	// PriorityGroupsParallel runs the exit callbacks that share the same
+	// Depth and Prio concurrently, as a group, while still running the
+	// groups themselves in reverse priority order. A panic in one group
+	// member is recovered on its own goroutine, same as Sequential, and
+	// does not cancel the other members of the group.
+	PriorityGroupsParallel
+)
+
+var executionMode = Sequential
+
+// SetExecutionMode sets how Execute runs the registered exit callbacks.
+//
+// Default: Sequential.
+func SetExecutionMode(mode ExecutionMode) { executionMode = mode }
+
+func runExitGroupsParallel(funcs []Func) {
+	for i := len(funcs) - 1; i >= 0; {
+		j := i
+		for j >= 0 && funcs[j].Depth == funcs[i].Depth && funcs[j].Prio == funcs[i].Prio {
+			j--
+		}
+		runExitGroup(funcs[j+1 : i+1])
+		i = j
+	}
+}
+
+func runExitGroup(group []Func) {
+	if len(group) == 1 {
+		group[0].runExit()
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(group))
+	for i := range group {
+		go func(f Func) {
+			defer wg.Done()
+			f.runExit()
+		}(group[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunExitGroupsParallel(t *testing.T) {
+	var running, maxRunning int32
+	track := func() {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 50)
+		atomic.AddInt32(&running, -1)
+	}
+
+	funcs := []Func{
+		{Prio: 1, Func: track},
+		{Prio: 2, Func: track},
+		{Prio: 2, Func: track},
+		{Prio: 2, Func: track},
+	}
+	sortfuncs(funcs)
+
+	start := time.Now()
+	runExitGroupsParallel(funcs)
+	elapsed := time.Since(start)
+
+	if elapsed < time.Millisecond*100 || elapsed > time.Millisecond*250 {
+		t.Errorf("expect about two sequential groups of 50ms, but took %s", elapsed)
+	}
+	if max := atomic.LoadInt32(&maxRunning); max != 3 {
+		t.Errorf("expect 3 callbacks to run concurrently, but got %d", max)
+	}
+}
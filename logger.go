@@ -0,0 +1,50 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import "log/slog"
+
+// Logger is used to print the debug and panic messages produced by
+// the registered init and exit callbacks.
+//
+// It follows the same call convention as "log/slog": msg is a short
+// human-readable message and args is a list of alternating key-value
+// pairs describing the structured attributes attached to the message.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// SetLogger sets the global logger used to print the debug and panic
+// messages.
+//
+// Default: a Logger bridging to log/slog.Default().
+func SetLogger(l Logger) {
+	if l == nil {
+		panic("atexit.SetLogger: logger is nil")
+	}
+	logger = l
+}
+
+var logger Logger = slogLogger{}
+
+// slogLogger is the default Logger, which bridges the package logging
+// to log/slog so that applications using structured logging can capture
+// the attributes, such as the panic stack trace, file/line and priority
+// of an exit callback, instead of parsing freeform stderr text.
+type slogLogger struct{}
+
+func (slogLogger) Debug(msg string, args ...interface{}) { slog.Debug(msg, args...) }
+func (slogLogger) Error(msg string, args ...interface{}) { slog.Error(msg, args...) }
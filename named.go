@@ -0,0 +1,168 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import "strings"
+
+// namedFunc is a node of a namedGraph: a registered callback plus the
+// names of the other nodes it depends on.
+type namedFunc struct {
+	Name string
+	Deps []string
+	Func Func
+}
+
+// namedGraph resolves the execution order of the callbacks registered
+// by OnInitNamed / OnExitNamed as a dependency DAG instead of a flat
+// priority list.
+type namedGraph struct {
+	order []string
+	nodes map[string]*namedFunc
+}
+
+func newNamedGraph() *namedGraph {
+	return &namedGraph{nodes: make(map[string]*namedFunc, 4)}
+}
+
+func (g *namedGraph) register(prefix, name string, deps []string, f Func) {
+	if name == "" {
+		panic(prefix + ": name must not be empty")
+	}
+	if _, ok := g.nodes[name]; ok {
+		panic(prefix + ": the name \"" + name + "\" has been registered")
+	}
+
+	g.nodes[name] = &namedFunc{Name: name, Deps: append([]string{}, deps...), Func: f}
+	g.order = append(g.order, name)
+	g.detectCycle(prefix, name)
+}
+
+// detectCycle panics with the offending dependency chain if registering
+// name has just closed a cycle in the graph built so far. It only needs
+// to walk from name, since the graph was acyclic before name was added.
+func (g *namedGraph) detectCycle(prefix, name string) {
+	const white, gray, black = 0, 1, 2
+	color := make(map[string]int, len(g.nodes))
+	chain := []string{name}
+
+	var walk func(string)
+	walk = func(n string) {
+		color[n] = gray
+		if node, ok := g.nodes[n]; ok {
+			for _, dep := range node.Deps {
+				switch color[dep] {
+				case gray:
+					chain = append(chain, dep)
+					panic(prefix + ": dependency cycle detected: " + strings.Join(chain, " -> "))
+				case white:
+					chain = append(chain, dep)
+					walk(dep)
+					chain = chain[:len(chain)-1]
+				}
+			}
+		}
+		color[n] = black
+	}
+	walk(name)
+}
+
+// resolve computes the topological depth of every registered node, which
+// is the length of its longest dependency chain, and returns the funcs
+// in registration order with Depth populated so that sortfuncs can place
+// them after everything they depend on.
+func (g *namedGraph) resolve(prefix string) []Func {
+	if len(g.order) == 0 {
+		return nil
+	}
+
+	depth := make(map[string]int, len(g.nodes))
+	var compute func(string) int
+	compute = func(name string) int {
+		if d, ok := depth[name]; ok {
+			return d
+		}
+
+		node, ok := g.nodes[name]
+		if !ok {
+			panic(prefix + ": dependency on the unregistered name \"" + name + "\"")
+		}
+
+		d := 0
+		for _, dep := range node.Deps {
+			if dd := compute(dep) + 1; dd > d {
+				d = dd
+			}
+		}
+		depth[name] = d
+		return d
+	}
+
+	funcs := make([]Func, len(g.order))
+	for i, name := range g.order {
+		node := g.nodes[name]
+		node.Func.Depth = compute(name)
+		funcs[i] = node.Func
+	}
+	return funcs
+}
+
+// dot renders the graph as the body of a Graphviz "digraph" subgraph,
+// with an edge from each dependency to the callback depending on it.
+func (g *namedGraph) dot(label string) string {
+	var b strings.Builder
+	b.WriteString("  subgraph cluster_" + label + " {\n")
+	b.WriteString("    label=\"" + label + "\";\n")
+	for _, name := range g.order {
+		node := g.nodes[name]
+		if len(node.Deps) == 0 {
+			b.WriteString("    \"" + name + "\";\n")
+			continue
+		}
+		for _, dep := range node.Deps {
+			b.WriteString("    \"" + dep + "\" -> \"" + name + "\";\n")
+		}
+	}
+	b.WriteString("  }\n")
+	return b.String()
+}
+
+var (
+	initGraph = newNamedGraph()
+	exitGraph = newNamedGraph()
+)
+
+func mergeNamed(funcs []Func, g *namedGraph, prefix string) []Func {
+	named := g.resolve(prefix)
+	if len(named) == 0 {
+		return funcs
+	}
+
+	merged := append(append([]Func{}, funcs...), named...)
+	sortfuncs(merged)
+	return merged
+}
+
+// DumpGraph returns a Graphviz DOT representation of the named init and
+// exit callback graphs registered by OnInitNamed and OnExitNamed, with
+// an edge from each dependency to the callback that depends on it. It
+// is mainly useful for debugging the resolved execution order.
+func DumpGraph() string {
+	var b strings.Builder
+	b.WriteString("digraph atexit {\n")
+	b.WriteString(initGraph.dot("init"))
+	b.WriteString(exitGraph.dot("exit"))
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNamedGraphResolve(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	initGraph = newNamedGraph()
+	defer func() { initGraph = newNamedGraph() }()
+
+	// Register out of dependency order, so a test that merely replays
+	// registration order instead of the resolved topological order fails.
+	OnInitNamed("db", []string{"logger"}, func() { buf.WriteString("db") })
+	OnInitNamed("http-server", []string{"db", "cache"}, func() { buf.WriteString("http-server") })
+	OnInitNamed("logger", nil, func() { buf.WriteString("logger") })
+	OnInitNamed("cache", []string{"logger"}, func() { buf.WriteString("cache") })
+
+	Init()
+
+	expect := "loggerdbcachehttp-server"
+	if s := buf.String(); s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+}
+
+func TestNamedGraphCycle(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expect a panic, but got none")
+		}
+	}()
+
+	g := newNamedGraph()
+	g.register("test", "a", []string{"c"}, Func{Func: func() {}})
+	g.register("test", "b", []string{"a"}, Func{Func: func() {}})
+	g.register("test", "c", []string{"b"}, Func{Func: func() {}})
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Handle identifies a callback registered by OnInit, OnInitWithPriority,
+// OnExit, OnExitWithPriority, or the same methods on a Scope, so that it
+// can later be removed with Unregister.
+type Handle struct {
+	id    uint64
+	scope *Scope
+}
+
+// Unregister removes the callback identified by h and reports whether it
+// was still registered. It is a no-op returning false for the zero
+// Handle.
+func Unregister(h Handle) bool {
+	if h.scope == nil {
+		return false
+	}
+	return h.scope.unregister(h.id)
+}
+
+// Scope is an independent set of init and exit callbacks with its own
+// OnInit, OnInitWithPriority, OnExit, OnExitWithPriority, Init, Execute
+// and Reset, so that libraries or tests can manage their own callbacks
+// without disturbing the package-level default Scope that backs the
+// free functions of the same names.
+//
+// A Scope only supports the priority-based callbacks: OnInitNamed,
+// OnExitNamed, OnInitContext, OnExitContext and ExecuteWithTimeout
+// operate solely on the package-level default Scope through the
+// like-named free functions.
+type Scope struct {
+	mu sync.Mutex
+
+	nextID   uint64
+	initprio int64
+	exitprio int64
+
+	initfuncs []Func
+	exitfuncs []Func
+
+	executed   uint32
+	exitexecch chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// NewScope creates an independent Scope.
+func NewScope() *Scope {
+	s := &Scope{initprio: 99, exitprio: 99}
+	s.reopen()
+	return s
+}
+
+var defaultScope = NewScope()
+
+func (s *Scope) reopen() {
+	s.exitexecch = make(chan struct{})
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+// Reset discards every callback registered on s and clears its Execute
+// state, so that s can be executed again. The priorities given by s.OnInit
+// and s.OnExit keep counting from where they were, and the Handles
+// returned before Reset never match a callback registered after it.
+//
+// Notice: Reset only affects s, not any other Scope.
+func (s *Scope) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initfuncs = nil
+	s.exitfuncs = nil
+	s.executed = 0
+	s.reopen()
+}
+
+func (s *Scope) unregister(id uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.initfuncs {
+		if f.id == id {
+			s.initfuncs = append(s.initfuncs[:i], s.initfuncs[i+1:]...)
+			return true
+		}
+	}
+	for i, f := range s.exitfuncs {
+		if f.id == id {
+			s.exitfuncs = append(s.exitfuncs[:i], s.exitfuncs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scope) registerInit(priority int, init func()) Handle {
+	const prefix = "atexit.OnInitWithPriority: init"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var h Handle
+	s.initfuncs, h = registerCallback(s, s.initfuncs, prefix, 2, priority, init)
+	return h
+}
+
+func (s *Scope) registerInitCtx(priority int, init func(context.Context) error) Handle {
+	const prefix = "atexit.OnInitContext: init callback"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var h Handle
+	s.initfuncs, h = registerCallbackCtx(s, s.initfuncs, prefix, 2, priority, init)
+	return h
+}
+
+func (s *Scope) registerExit(priority int, exit func()) Handle {
+	const prefix = "atexit.OnExitWithPriority: exit callback"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var h Handle
+	s.exitfuncs, h = registerCallback(s, s.exitfuncs, prefix, 2, priority, exit)
+	return h
+}
+
+func (s *Scope) registerExitCtx(priority int, exit func(context.Context) error) Handle {
+	const prefix = "atexit.OnExitContext: exit callback"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var h Handle
+	s.exitfuncs, h = registerCallbackCtx(s, s.exitfuncs, prefix, 2, priority, exit)
+	return h
+}
+
+func (s *Scope) getInitFuncs() []Func {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Func{}, s.initfuncs...)
+}
+
+func (s *Scope) getExitFuncs() []Func {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Func{}, s.exitfuncs...)
+}
+
+// OnInitWithPriority registers the init function with the priority on s,
+// which will be called when calling s.Init.
+//
+// Notice: The smaller the value, the higher the priority.
+func (s *Scope) OnInitWithPriority(priority int, init func()) Handle {
+	return s.registerInit(priority, init)
+}
+
+// OnInit is the same as OnInitWithPriority, but increase the priority
+// starting with 100, just as the package-level OnInit does for the
+// default Scope.
+func (s *Scope) OnInit(init func()) Handle {
+	return s.registerInit(int(atomic.AddInt64(&s.initprio, 1)), init)
+}
+
+// OnExitWithPriority registers the exit callback function with the
+// priority on s, which will be called when calling s.Execute.
+//
+// Notice: The bigger the value, the higher the priority.
+func (s *Scope) OnExitWithPriority(priority int, callback func()) Handle {
+	return s.registerExit(priority, callback)
+}
+
+// OnExit is the same as OnExitWithPriority, but increase the priority
+// starting with 100, just as the package-level OnExit does for the
+// default Scope.
+func (s *Scope) OnExit(callback func()) Handle {
+	return s.registerExit(int(atomic.AddInt64(&s.exitprio, 1)), callback)
+}
+
+// Init calls all the init functions registered on s.
+func (s *Scope) Init() {
+	runInits(s.getInitFuncs())
+}
+
+// Execute calls all the exit functions registered on s in reverse.
+//
+// Notice: The exit functions of s are executed only once.
+func (s *Scope) Execute() {
+	if atomic.CompareAndSwapUint32(&s.executed, 0, 1) {
+		s.cancel()
+		runExits(s.getExitFuncs())
+		close(s.exitexecch)
+	}
+}
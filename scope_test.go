@@ -0,0 +1,80 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import "testing"
+
+func TestScopeResetIsolated(t *testing.T) {
+	s1 := NewScope()
+	s2 := NewScope()
+
+	s1.OnInit(func() {})
+	s2.OnInit(func() {})
+	s2.OnExit(func() {})
+
+	s1.Reset()
+
+	if n := len(s1.getInitFuncs()); n != 0 {
+		t.Errorf("s1: expect 0 init funcs after Reset, but got %d", n)
+	}
+	if n := len(s2.getInitFuncs()); n != 1 {
+		t.Errorf("s2: expect 1 init func untouched by s1.Reset, but got %d", n)
+	}
+	if n := len(s2.getExitFuncs()); n != 1 {
+		t.Errorf("s2: expect 1 exit func untouched by s1.Reset, but got %d", n)
+	}
+}
+
+func TestScopeUnregister(t *testing.T) {
+	s := NewScope()
+
+	h1 := s.OnInit(func() {})
+	h2 := s.OnInit(func() {})
+	h3 := s.OnInit(func() {})
+
+	before := s.getInitFuncs()
+	line2 := before[1].Line
+
+	if !Unregister(h2) {
+		t.Fatal("expect Unregister to report the handle as found")
+	}
+
+	funcs := s.getInitFuncs()
+	if n := len(funcs); n != 2 {
+		t.Fatalf("expect 2 init funcs after Unregister, but got %d", n)
+	}
+	if funcs[0].Line != before[0].Line || funcs[1].Line != before[2].Line {
+		t.Errorf("expect the remaining funcs' line numbers undisturbed, got %v", funcs)
+	}
+	if funcs[0].Line == line2 || funcs[1].Line == line2 {
+		t.Errorf("the unregistered func's line %d must not remain", line2)
+	}
+
+	if Unregister(h2) {
+		t.Error("expect a second Unregister of the same handle to report false")
+	}
+	if !Unregister(h1) || !Unregister(h3) {
+		t.Error("expect the two remaining handles to still unregister")
+	}
+	if n := len(s.getInitFuncs()); n != 0 {
+		t.Errorf("expect 0 init funcs left, but got %d", n)
+	}
+}
+
+func TestUnregisterZeroHandle(t *testing.T) {
+	if Unregister(Handle{}) {
+		t.Error("expect Unregister of the zero Handle to report false")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atexit
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// InstallShutdownHandler installs a handler for the given signals that
+// turns them into a graceful, then forceful, shutdown:
+//
+//   - On the first signal, it runs Execute in the background so the
+//     registered exit callbacks start cleaning up.
+//   - If the very same signal is received again before Execute has
+//     finished, it calls ExitFunc(130) immediately, the classic
+//     "Ctrl-C twice to force-quit" behavior.
+//
+// Before the first signal, the handler stops watching for signals,
+// without acting, as soon as ctx is done or the exit callbacks are
+// already executing, i.e. as soon as Context().Done() fires, which makes
+// it idempotent when combined with other callers of Execute or with the
+// Once/Loop helpers of the signal subpackage watching different signals.
+// After the first signal, it stops once ctx is done or Execute finishes.
+func InstallShutdownHandler(ctx context.Context, signals ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		var sig os.Signal
+		select {
+		case <-ctx.Done():
+			return
+		case <-Context().Done():
+			return
+		case sig = <-ch:
+			go Execute()
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-defaultScope.exitexecch:
+				return
+			case sig2 := <-ch:
+				if sig2 == sig {
+					ExitFunc(130)
+					return
+				}
+				sig = sig2
+			}
+		}
+	}()
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix || aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build unix aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package atexit
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInstallShutdownHandlerStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := make(chan int, 1)
+	old := ExitFunc
+	ExitFunc = func(code int) { called <- code }
+	defer func() { ExitFunc = old }()
+
+	InstallShutdownHandler(ctx, syscall.SIGUSR1)
+
+	time.Sleep(time.Millisecond * 50)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	time.Sleep(time.Millisecond * 50)
+
+	select {
+	case code := <-called:
+		t.Errorf("ExitFunc must not be called once ctx is already done, got code %d", code)
+	default:
+	}
+}
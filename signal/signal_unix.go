@@ -0,0 +1,67 @@
+// Copyright 2022 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix || aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build unix aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+// Package signal is used to wrap the standard library package "os/signal"
+// to help register and handle the process signals.
+package signal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Callback is the callback function called when one of the watched
+// signals is received.
+type Callback func()
+
+// Once waits for one of the signals to arrive, then calls cb once and
+// returns. It returns early, without calling cb, if ctx is done first.
+func Once(ctx context.Context, cb Callback, sigs ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	select {
+	case <-ctx.Done():
+	case <-ch:
+		cb()
+	}
+}
+
+// Loop waits for one of the signals to arrive and calls cb each time,
+// until ctx is done.
+func Loop(ctx context.Context, cb Callback, sigs ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			cb()
+		}
+	}
+}
+
+// Kill sends the signal to the process identified by pid.
+func Kill(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
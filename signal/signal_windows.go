@@ -0,0 +1,179 @@
+// Copyright 2023 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package signal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// Callback is the callback function called when one of the watched
+// signals is received.
+type Callback func()
+
+// ctrlSignal represents a Windows console control event that the Go
+// runtime does not already surface through os/signal: CTRL_C_EVENT and
+// CTRL_BREAK_EVENT arrive as os.Interrupt, but CTRL_CLOSE_EVENT,
+// CTRL_LOGOFF_EVENT and CTRL_SHUTDOWN_EVENT do not, so Once and Loop
+// forward them as one of the values below instead.
+type ctrlSignal uint32
+
+func (s ctrlSignal) Signal() {}
+func (s ctrlSignal) String() string {
+	switch uint32(s) {
+	case windows.CTRL_CLOSE_EVENT:
+		return "console-close"
+	case windows.CTRL_LOGOFF_EVENT:
+		return "console-logoff"
+	case windows.CTRL_SHUTDOWN_EVENT:
+		return "console-shutdown"
+	default:
+		return "console-ctrl"
+	}
+}
+
+// The console control events not already delivered as os.Interrupt.
+// Pass one of these to Once or Loop to also watch for it.
+const (
+	CtrlClose    = ctrlSignal(windows.CTRL_CLOSE_EVENT)
+	CtrlLogoff   = ctrlSignal(windows.CTRL_LOGOFF_EVENT)
+	CtrlShutdown = ctrlSignal(windows.CTRL_SHUTDOWN_EVENT)
+)
+
+var (
+	kernel32                  = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleCtrlHandler = kernel32.NewProc("SetConsoleCtrlHandler")
+
+	ctrlHandlerOnce sync.Once
+	ctrlMutex       sync.Mutex
+	ctrlChans       = map[chan os.Signal]struct{}{}
+)
+
+// installCtrlHandler registers, once per process, a console control
+// handler that forwards CTRL_CLOSE_EVENT, CTRL_LOGOFF_EVENT and
+// CTRL_SHUTDOWN_EVENT to every channel currently watching for them. It
+// lets CTRL_C_EVENT and CTRL_BREAK_EVENT fall through to the default
+// handler, which is where the Go runtime turns them into os.Interrupt.
+func installCtrlHandler() {
+	ctrlHandlerOnce.Do(func() {
+		handler := func(ctrlType uint32) uintptr {
+			switch ctrlType {
+			case windows.CTRL_CLOSE_EVENT, windows.CTRL_LOGOFF_EVENT, windows.CTRL_SHUTDOWN_EVENT:
+				sig := ctrlSignal(ctrlType)
+				ctrlMutex.Lock()
+				for ch := range ctrlChans {
+					select {
+					case ch <- sig:
+					default:
+					}
+				}
+				ctrlMutex.Unlock()
+				return 1 // TRUE: handled, do not run the default handler.
+			default:
+				return 0 // FALSE: let the default handler run.
+			}
+		}
+		procSetConsoleCtrlHandler.Call(syscall.NewCallback(handler), 1)
+	})
+}
+
+func watch(sigs []os.Signal) (ch chan os.Signal, watchesCtrl bool) {
+	ch = make(chan os.Signal, 1)
+
+	var stdsigs []os.Signal
+	for _, sig := range sigs {
+		if _, ok := sig.(ctrlSignal); ok {
+			watchesCtrl = true
+			continue
+		}
+		stdsigs = append(stdsigs, sig)
+	}
+
+	if len(stdsigs) > 0 {
+		signal.Notify(ch, stdsigs...)
+	}
+	if watchesCtrl {
+		installCtrlHandler()
+		ctrlMutex.Lock()
+		ctrlChans[ch] = struct{}{}
+		ctrlMutex.Unlock()
+	}
+
+	return ch, watchesCtrl
+}
+
+func unwatch(ch chan os.Signal, watchesCtrl bool) {
+	signal.Stop(ch)
+	if watchesCtrl {
+		ctrlMutex.Lock()
+		delete(ctrlChans, ch)
+		ctrlMutex.Unlock()
+	}
+}
+
+// Once waits for one of the signals to arrive, then calls cb once and
+// returns. It returns early, without calling cb, if ctx is done first.
+func Once(ctx context.Context, cb Callback, sigs ...os.Signal) {
+	ch, watchesCtrl := watch(sigs)
+	defer unwatch(ch, watchesCtrl)
+
+	select {
+	case <-ctx.Done():
+	case <-ch:
+		cb()
+	}
+}
+
+// Loop waits for one of the signals to arrive and calls cb each time,
+// until ctx is done.
+func Loop(ctx context.Context, cb Callback, sigs ...os.Signal) {
+	ch, watchesCtrl := watch(sigs)
+	defer unwatch(ch, watchesCtrl)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			cb()
+		}
+	}
+}
+
+// Kill raises sig for the current process. Only SIGINT and SIGTERM are
+// supported, both delivered as CTRL_C_EVENT, since Windows has no
+// equivalent of POSIX kill(2) to target an arbitrary process with an
+// arbitrary signal; any other signal returns an error.
+//
+// Windows only honors CTRL_C_EVENT for process group 0, the group
+// containing the calling process, and never for a nonzero group, so pid
+// is accepted only to match the signature of the unix Kill and is
+// otherwise unused.
+func Kill(pid int, sig syscall.Signal) error {
+	switch sig {
+	case syscall.SIGINT, syscall.SIGTERM:
+		return windows.GenerateConsoleCtrlEvent(windows.CTRL_C_EVENT, 0)
+	default:
+		return errors.New("signal: unsupported signal on windows")
+	}
+}